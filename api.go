@@ -0,0 +1,313 @@
+package egobee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ecobeeAPIBaseURL is the root of the ecobee data API, as distinct from
+// ecobeeAuthURL/ecobeeTokenURL which handle authorization.
+const ecobeeAPIBaseURL = "https://api.ecobee.com/1"
+
+// Status is the envelope ecobee wraps every API response in.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Status.shtml
+type Status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIError is returned when an ecobee API response's status code is
+// non-zero.
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ecobee API error %d: %v", e.Code, e.Message)
+}
+
+// statusErr turns a non-zero Status into an *APIError, or nil if s
+// indicates success.
+func statusErr(s Status) error {
+	if s.Code == 0 {
+		return nil
+	}
+	return &APIError{Code: s.Code, Message: s.Message}
+}
+
+// SelectionType controls which thermostats a Selection matches.
+type SelectionType string
+
+// Possible SelectionTypes.
+var (
+	SelectionTypeRegistered    SelectionType = "registered"
+	SelectionTypeThermostats   SelectionType = "thermostats"
+	SelectionTypeManagementSet SelectionType = "managementSet"
+)
+
+// Selection narrows which thermostats, and which of their fields, an API
+// call operates on.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Selection.shtml
+type Selection struct {
+	SelectionType  SelectionType `json:"selectionType"`
+	SelectionMatch string        `json:"selectionMatch"`
+
+	IncludeSettings        bool `json:"includeSettings,omitempty"`
+	IncludeRuntime         bool `json:"includeRuntime,omitempty"`
+	IncludeExtendedRuntime bool `json:"includeExtendedRuntime,omitempty"`
+	IncludeEvents          bool `json:"includeEvents,omitempty"`
+	IncludeSensors         bool `json:"includeSensors,omitempty"`
+	IncludeWeather         bool `json:"includeWeather,omitempty"`
+	IncludeEquipmentStatus bool `json:"includeEquipmentStatus,omitempty"`
+}
+
+// ThermostatSelection selects which thermostats, and which of their
+// fields, Thermostats should return.
+type ThermostatSelection = Selection
+
+// AllRegisteredThermostats returns a Selection matching every thermostat
+// registered to the authenticated account.
+func AllRegisteredThermostats() Selection {
+	return Selection{SelectionType: SelectionTypeRegistered}
+}
+
+// ThermostatsByID returns a Selection matching only the given thermostat
+// identifiers.
+func ThermostatsByID(ids ...string) Selection {
+	return Selection{SelectionType: SelectionTypeThermostats, SelectionMatch: strings.Join(ids, ",")}
+}
+
+// Page reports where a paginated response falls within the full result
+// set.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Page.shtml
+type Page struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"totalPages"`
+	PageSize   int `json:"pageSize"`
+	Total      int `json:"total"`
+}
+
+// Function is an ecobee "functions" object: a named action sent to
+// UpdateThermostat, such as those returned by SetHold, ResumeProgram,
+// CreateVacation, and SendMessage.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/functions/Functions.shtml
+type Function struct {
+	Type   string      `json:"type"`
+	Params interface{} `json:"params,omitempty"`
+}
+
+// SetHold returns a Function which holds the thermostat at heatTemp and
+// coolTemp until holdType expires.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/functions/SetHold.shtml
+func SetHold(heatTemp, coolTemp int, holdType string) Function {
+	return Function{
+		Type: "setHold",
+		Params: map[string]interface{}{
+			"heatHoldTemp": heatTemp,
+			"coolHoldTemp": coolTemp,
+			"holdType":     holdType,
+		},
+	}
+}
+
+// ResumeProgram returns a Function which cancels the currently running
+// hold or vacation, resuming the thermostat's program.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/functions/ResumeProgram.shtml
+func ResumeProgram(resumeAll bool) Function {
+	return Function{
+		Type:   "resumeProgram",
+		Params: map[string]interface{}{"resumeAll": resumeAll},
+	}
+}
+
+// CreateVacation returns a Function which schedules a vacation event.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/functions/CreateVacation.shtml
+func CreateVacation(name string, coolTemp, heatTemp int, startDate, startTime, endDate, endTime string) Function {
+	return Function{
+		Type: "createVacation",
+		Params: map[string]interface{}{
+			"name":         name,
+			"coolHoldTemp": coolTemp,
+			"heatHoldTemp": heatTemp,
+			"startDate":    startDate,
+			"startTime":    startTime,
+			"endDate":      endDate,
+			"endTime":      endTime,
+		},
+	}
+}
+
+// SendMessage returns a Function which displays text on the thermostat.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/functions/SendMessage.shtml
+func SendMessage(text string) Function {
+	return Function{
+		Type:   "sendMessage",
+		Params: map[string]interface{}{"text": text},
+	}
+}
+
+type thermostatRequest struct {
+	Selection Selection `json:"selection"`
+	Page      *Page     `json:"page,omitempty"`
+}
+
+type thermostatResponse struct {
+	Status      Status       `json:"status"`
+	Page        *Page        `json:"page,omitempty"`
+	Thermostats []Thermostat `json:"thermostatList"`
+}
+
+// Thermostats returns every thermostat matched by sel, populated with
+// whichever fields sel requested. Large accounts can spread a Selection
+// match across multiple pages; Thermostats follows Page.TotalPages
+// internally so callers always get the full match in one call.
+func (c *Client) Thermostats(ctx context.Context, sel ThermostatSelection) ([]Thermostat, error) {
+	var all []Thermostat
+	req := thermostatRequest{Selection: sel}
+	for {
+		var resp thermostatResponse
+		if err := c.getJSON(ctx, "/thermostat", req, &resp); err != nil {
+			return nil, err
+		}
+		if err := statusErr(resp.Status); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Thermostats...)
+		if resp.Page == nil || resp.Page.Page >= resp.Page.TotalPages {
+			return all, nil
+		}
+		req.Page = &Page{Page: resp.Page.Page + 1}
+	}
+}
+
+// RuntimeReportRequest selects which thermostats' runtime report columns
+// to return, and over what date range.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/operations/get-runtime-report.shtml
+type RuntimeReportRequest struct {
+	Selection      Selection `json:"selection"`
+	StartDate      string    `json:"startDate"`
+	EndDate        string    `json:"endDate"`
+	StartInterval  int       `json:"startInterval,omitempty"`
+	EndInterval    int       `json:"endInterval,omitempty"`
+	Columns        string    `json:"columns"`
+	IncludeSensors bool      `json:"includeSensors,omitempty"`
+}
+
+// RuntimeReportRow holds one thermostat's reported column values, one
+// comma-separated entry per 5-minute interval in the requested range.
+type RuntimeReportRow struct {
+	ThermostatIdentifier string   `json:"thermostatIdentifier"`
+	RowList              []string `json:"rowList"`
+}
+
+// SensorRuntimeReportColumn names one column of a
+// SensorRuntimeReportRow.
+type SensorRuntimeReportColumn struct {
+	SensorID string `json:"sensorId"`
+	Column   string `json:"columnName"`
+}
+
+// SensorRuntimeReportRow holds one remote sensor's reported readings.
+type SensorRuntimeReportRow struct {
+	SensorID   string                      `json:"sensorId"`
+	SensorName string                      `json:"sensorName"`
+	Type       string                      `json:"type"`
+	Columns    []SensorRuntimeReportColumn `json:"columns"`
+	Data       []string                    `json:"data"`
+}
+
+// RuntimeReport is the response to RuntimeReport.
+type RuntimeReport struct {
+	StartDate  string                   `json:"startDate"`
+	EndDate    string                   `json:"endDate"`
+	Columns    string                   `json:"columns"`
+	Rows       []RuntimeReportRow       `json:"reportList"`
+	SensorRows []SensorRuntimeReportRow `json:"sensorList,omitempty"`
+}
+
+type runtimeReportResponse struct {
+	Status Status `json:"status"`
+	RuntimeReport
+}
+
+// RuntimeReport fetches historical runtime data for the thermostats and
+// columns req selects.
+func (c *Client) RuntimeReport(ctx context.Context, req RuntimeReportRequest) (*RuntimeReport, error) {
+	var resp runtimeReportResponse
+	if err := c.getJSON(ctx, "/runtimeReport", req, &resp); err != nil {
+		return nil, err
+	}
+	if err := statusErr(resp.Status); err != nil {
+		return nil, err
+	}
+	rr := resp.RuntimeReport
+	return &rr, nil
+}
+
+// ThermostatUpdate describes a write to one or more thermostats: the
+// Selection of which thermostats to target, optional ThermostatWrite
+// fields to overwrite directly, and Functions to invoke (see SetHold,
+// ResumeProgram, CreateVacation, SendMessage).
+// See https://www.ecobee.com/home/developer/api/documentation/v1/operations/post-update-thermostat.shtml
+type ThermostatUpdate struct {
+	Selection  Selection        `json:"selection"`
+	Thermostat *ThermostatWrite `json:"thermostat,omitempty"`
+	Functions  []Function       `json:"functions,omitempty"`
+}
+
+// UpdateThermostat applies u to the thermostats it selects.
+func (c *Client) UpdateThermostat(ctx context.Context, u ThermostatUpdate) error {
+	var resp struct {
+		Status Status `json:"status"`
+	}
+	if err := c.postJSON(ctx, "/thermostat", u, &resp); err != nil {
+		return err
+	}
+	return statusErr(resp.Status)
+}
+
+// getJSON issues a GET to the ecobee API, encoding body as the "json"
+// query parameter ecobee's read operations expect, and decodes the
+// response into out.
+func (c *Client) getJSON(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	v := url.Values{"json": {string(payload)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ecobeeAPIBaseURL+path+"?"+v.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(req, out)
+}
+
+// postJSON issues a POST of body to the ecobee API and decodes the
+// response into out.
+func (c *Client) postJSON(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ecobeeAPIBaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.doJSON(req, out)
+}
+
+func (c *Client) doJSON(req *http.Request, out interface{}) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
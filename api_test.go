@@ -0,0 +1,231 @@
+package egobee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// redirectTransport forwards every request to target, regardless of the
+// URL it was built against, so tests can point Client at an httptest
+// server without overriding the package-level ecobeeAPIBaseURL constant.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestClient(server *httptest.Server) *Client {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		panic(err)
+	}
+	return &Client{Client: http.Client{Transport: &redirectTransport{target: u}}}
+}
+
+// TestClient_Thermostats_Paginates verifies Thermostats follows
+// Page.TotalPages internally, aggregating every page's results instead of
+// silently truncating to the first page.
+func TestClient_Thermostats_Paginates(t *testing.T) {
+	var requests []thermostatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req thermostatRequest
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("json")), &req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+
+		page := 1
+		if req.Page != nil {
+			page = req.Page.Page
+		}
+		resp := thermostatResponse{
+			Page: &Page{Page: page, TotalPages: 2},
+		}
+		switch page {
+		case 1:
+			resp.Thermostats = []Thermostat{{Identifier: "therm1"}}
+		case 2:
+			resp.Thermostats = []Thermostat{{Identifier: "therm2"}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	got, err := c.Thermostats(context.Background(), AllRegisteredThermostats())
+	if err != nil {
+		t.Fatalf("Thermostats: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2 (one per page)", len(requests))
+	}
+	if requests[1].Page == nil || requests[1].Page.Page != 2 {
+		t.Errorf("second request's Page: got %+v, want {Page: 2}", requests[1].Page)
+	}
+
+	want := []string{"therm1", "therm2"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d thermostats, want %d", len(got), len(want))
+	}
+	for i, id := range want {
+		if got[i].Identifier != id {
+			t.Errorf("thermostat %d: got identifier %q, want %q", i, got[i].Identifier, id)
+		}
+	}
+}
+
+// TestThermostatWrite_MarshalOmitsUnsetFields guards against
+// ThermostatUpdate.Thermostat sending zero-valued fields (a falsely
+// "false" isRegistered, an empty name, an empty runtime/weather object,
+// etc.) alongside whichever Settings fields a caller actually meant to
+// write.
+func TestThermostatWrite_MarshalOmitsUnsetFields(t *testing.T) {
+	u := ThermostatUpdate{
+		Selection:  ThermostatsByID("123456789012"),
+		Thermostat: &ThermostatWrite{Settings: &Settings{HVACMode: "auto"}},
+	}
+	b, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	thermostat, ok := got["thermostat"].(map[string]interface{})
+	if !ok {
+		t.Fatalf(`got["thermostat"] = %#v, want a JSON object`, got["thermostat"])
+	}
+	if len(thermostat) != 1 {
+		t.Errorf("thermostat object: got %v, want only a settings field", thermostat)
+	}
+	settings, _ := thermostat["settings"].(map[string]interface{})
+	if len(settings) != 1 || settings["hvacMode"] != "auto" {
+		t.Errorf(`thermostat["settings"]: got %v, want {"hvacMode":"auto"}`, settings)
+	}
+}
+
+func TestClient_Thermostats_SinglePage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(thermostatResponse{
+			Thermostats: []Thermostat{{Identifier: "only"}},
+		})
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	got, err := c.Thermostats(context.Background(), AllRegisteredThermostats())
+	if err != nil {
+		t.Fatalf("Thermostats: %v", err)
+	}
+	if len(got) != 1 || got[0].Identifier != "only" {
+		t.Errorf("got %+v, want a single thermostat %q", got, "only")
+	}
+}
+
+// TestClient_UpdateThermostat_RequestBody asserts the JSON body
+// UpdateThermostat actually sends ecobee, which is what would have caught
+// ThermostatWrite sending zero-valued fields alongside the intended
+// Settings change.
+func TestClient_UpdateThermostat_RequestBody(t *testing.T) {
+	var got ThermostatUpdate
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		var raw map[string]interface{}
+		body, _ := json.Marshal(got)
+		if err := json.Unmarshal(body, &raw); err != nil {
+			t.Fatalf("re-marshaling request for inspection: %v", err)
+		}
+		if thermostat, ok := raw["thermostat"].(map[string]interface{}); ok {
+			if len(thermostat) != 1 {
+				t.Errorf(`request "thermostat" object: got %v, want only a settings field`, thermostat)
+			}
+		} else {
+			t.Errorf(`request has no "thermostat" object: %v`, raw)
+		}
+		json.NewEncoder(w).Encode(struct {
+			Status Status `json:"status"`
+		}{})
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	u := ThermostatUpdate{
+		Selection:  ThermostatsByID("123456789012"),
+		Thermostat: &ThermostatWrite{Settings: &Settings{HVACMode: "auto"}},
+	}
+	if err := c.UpdateThermostat(context.Background(), u); err != nil {
+		t.Fatalf("UpdateThermostat: %v", err)
+	}
+
+	if got.Selection.SelectionMatch != "123456789012" {
+		t.Errorf("request Selection.SelectionMatch: got %q, want %q", got.Selection.SelectionMatch, "123456789012")
+	}
+	if got.Thermostat == nil || got.Thermostat.Settings == nil || got.Thermostat.Settings.HVACMode != "auto" {
+		t.Errorf("request Thermostat: got %+v, want Settings.HVACMode %q", got.Thermostat, "auto")
+	}
+}
+
+func errorStatusHandler(code int, msg string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(struct {
+			Status Status `json:"status"`
+		}{Status: Status{Code: code, Message: msg}})
+	}
+}
+
+// TestClient_NonZeroStatus_SurfacesAsAPIError covers the status.code ->
+// *APIError path for each of the endpoints that decode a Status envelope.
+func TestClient_NonZeroStatus_SurfacesAsAPIError(t *testing.T) {
+	server := httptest.NewServer(errorStatusHandler(14, "token expired"))
+	defer server.Close()
+	c := newTestClient(server)
+
+	t.Run("Thermostats", func(t *testing.T) {
+		_, err := c.Thermostats(context.Background(), AllRegisteredThermostats())
+		assertAPIError(t, err, 14)
+	})
+
+	t.Run("RuntimeReport", func(t *testing.T) {
+		_, err := c.RuntimeReport(context.Background(), RuntimeReportRequest{
+			Selection: AllRegisteredThermostats(),
+			Columns:   "zoneAveTemp",
+		})
+		assertAPIError(t, err, 14)
+	})
+
+	t.Run("UpdateThermostat", func(t *testing.T) {
+		err := c.UpdateThermostat(context.Background(), ThermostatUpdate{
+			Selection: AllRegisteredThermostats(),
+		})
+		assertAPIError(t, err, 14)
+	})
+}
+
+func assertAPIError(t *testing.T, err error, wantCode int) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("got nil error, want a non-zero status to surface as *APIError")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got error of type %T (%v), want *APIError", err, err)
+	}
+	if apiErr.Code != wantCode {
+		t.Errorf("APIError.Code: got %d, want %d", apiErr.Code, wantCode)
+	}
+}
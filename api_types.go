@@ -0,0 +1,129 @@
+package egobee
+
+// Thermostat mirrors the ecobee Thermostat object.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Thermostat.shtml
+type Thermostat struct {
+	Identifier     string `json:"identifier"`
+	Name           string `json:"name"`
+	ThermostatRev  string `json:"thermostatRev"`
+	IsRegistered   bool   `json:"isRegistered"`
+	ModelNumber    string `json:"modelNumber"`
+	Brand          string `json:"brand"`
+	Features       string `json:"features"`
+	LastModified   string `json:"lastModified"`
+	ThermostatTime string `json:"thermostatTime"`
+	UTCTime        string `json:"utcTime"`
+
+	Settings        Settings        `json:"settings,omitempty"`
+	Runtime         Runtime         `json:"runtime,omitempty"`
+	ExtendedRuntime ExtendedRuntime `json:"extendedRuntime,omitempty"`
+	Events          []Event         `json:"events,omitempty"`
+	Sensors         []Sensor        `json:"remoteSensors,omitempty"`
+	Weather         Weather         `json:"weather,omitempty"`
+	EquipmentStatus string          `json:"equipmentStatus,omitempty"`
+}
+
+// Settings mirrors the fields of the ecobee Settings object most callers
+// need in order to read and write HVAC behavior.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Settings.shtml
+type Settings struct {
+	HVACMode            string `json:"hvacMode,omitempty"`
+	HeatStages          int    `json:"heatStages,omitempty"`
+	CoolStages          int    `json:"coolStages,omitempty"`
+	UseCelsius          bool   `json:"useCelsius,omitempty"`
+	HeatCoolMinDelta    int    `json:"heatCoolMinDelta,omitempty"`
+	Vent                string `json:"vent,omitempty"`
+	VentilatorMinOnTime int    `json:"ventilatorMinOnTime,omitempty"`
+	FanMinOnTime        int    `json:"fanMinOnTime,omitempty"`
+	AutoAway            bool   `json:"autoAway,omitempty"`
+	FollowMeComfort     bool   `json:"followMeComfort,omitempty"`
+}
+
+// Runtime mirrors the ecobee Runtime object: the thermostat's current
+// sensor readings and setpoints.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Runtime.shtml
+type Runtime struct {
+	LastStatusModified string `json:"lastStatusModified,omitempty"`
+	RuntimeRev         string `json:"runtimeRev,omitempty"`
+	Connected          bool   `json:"connected,omitempty"`
+	ActualTemperature  int    `json:"actualTemperature,omitempty"`
+	ActualHumidity     int    `json:"actualHumidity,omitempty"`
+	DesiredHeat        int    `json:"desiredHeat,omitempty"`
+	DesiredCool        int    `json:"desiredCool,omitempty"`
+	DesiredHumidity    int    `json:"desiredHumidity,omitempty"`
+	DesiredDehumidity  int    `json:"desiredDehumidity,omitempty"`
+	DesiredFanMode     string `json:"desiredFanMode,omitempty"`
+}
+
+// ExtendedRuntime mirrors the ecobee ExtendedRuntime object: the last
+// three 5-minute interval readings reported by the thermostat.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/ExtendedRuntime.shtml
+type ExtendedRuntime struct {
+	LastReadingTimestamp string    `json:"lastReadingTimestamp,omitempty"`
+	ActualTemperature    [3]int    `json:"actualTemperature,omitempty"`
+	ActualHumidity       [3]int    `json:"actualHumidity,omitempty"`
+	DesiredHeat          [3]int    `json:"desiredHeat,omitempty"`
+	DesiredCool          [3]int    `json:"desiredCool,omitempty"`
+	HVACMode             [3]string `json:"hvacMode,omitempty"`
+}
+
+// Event mirrors the ecobee Event object: holds, vacations, and other
+// overrides of the thermostat's running program.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Event.shtml
+type Event struct {
+	Type                  string `json:"type"`
+	Name                  string `json:"name,omitempty"`
+	Running               bool   `json:"running,omitempty"`
+	StartDate             string `json:"startDate,omitempty"`
+	StartTime             string `json:"startTime,omitempty"`
+	EndDate               string `json:"endDate,omitempty"`
+	EndTime               string `json:"endTime,omitempty"`
+	IsTemperatureRelative bool   `json:"isTemperatureRelative,omitempty"`
+	CoolHoldTemp          int    `json:"coolHoldTemp,omitempty"`
+	HeatHoldTemp          int    `json:"heatHoldTemp,omitempty"`
+	Fan                   string `json:"fan,omitempty"`
+	HoldClimateRef        string `json:"holdClimateRef,omitempty"`
+}
+
+// Sensor mirrors the ecobee RemoteSensor object.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/RemoteSensor.shtml
+type Sensor struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Type       string             `json:"type"`
+	Code       string             `json:"code,omitempty"`
+	InUse      bool               `json:"inUse,omitempty"`
+	Capability []SensorCapability `json:"capability,omitempty"`
+}
+
+// SensorCapability mirrors the ecobee RemoteSensorCapability object.
+type SensorCapability struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Weather mirrors the ecobee Weather object.
+// See https://www.ecobee.com/home/developer/api/documentation/v1/objects/Weather.shtml
+type Weather struct {
+	Timestamp      string            `json:"timestamp,omitempty"`
+	WeatherStation string            `json:"weatherStation,omitempty"`
+	Forecasts      []WeatherForecast `json:"forecasts,omitempty"`
+}
+
+// WeatherForecast mirrors the ecobee WeatherForecast object.
+type WeatherForecast struct {
+	DateTime    string `json:"dateTime,omitempty"`
+	Temperature int    `json:"temperature,omitempty"`
+	Humidity    int    `json:"relativeHumidity,omitempty"`
+	Condition   string `json:"condition,omitempty"`
+}
+
+// ThermostatWrite is the subset of Thermostat that ecobee's
+// updateThermostat "thermostat" field actually accepts writes to.
+// Unlike Thermostat, which mirrors the full read object, Settings is a
+// pointer so a ThermostatWrite that only sets a couple of fields omits
+// Settings entirely rather than sending the rest of it zeroed out.
+type ThermostatWrite struct {
+	Settings *Settings `json:"settings,omitempty"`
+}
@@ -3,14 +3,27 @@
 package egobee
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"log"
+	"math/rand"
 	"net/http"
+	"net/url"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
-const ecobeeTokenURL = "https://api.ecobee.com/token"
+const (
+	ecobeeTokenURL = "https://api.ecobee.com/token"
+
+	// defaultReauthThreshold is used when Client/authorizingTransport
+	// doesn't specify a ReauthThreshold of its own.
+	defaultReauthThreshold = 15 * time.Second
+
+	initialReauthBackoff = 500 * time.Millisecond
+	maxReauthBackoff     = 30 * time.Second
+	maxReauthAttempts    = 5
+)
 
 type reauthResponse struct {
 	Err  *AuthorizationErrorResponse
@@ -26,9 +39,39 @@ func (r *reauthResponse) ok() bool {
 
 func (r *reauthResponse) err() error {
 	if r.Err != nil && r.Err.Error != "" && r.Err.Description != "" {
-		return fmt.Errorf("unable to re-authenticate: %v: %v", r.Err.Error, r.Err.Description)
+		return &ReauthError{
+			Reason: r.Err.Error,
+			msg:    fmt.Sprintf("unable to re-authenticate: %v: %v", r.Err.Error, r.Err.Description),
+		}
 	}
-	return errors.New("unable to re-authenticate for unknown reasons")
+	return &ReauthError{msg: "unable to re-authenticate for unknown reasons"}
+}
+
+// ReauthError is returned when a token refresh ultimately fails. Reason
+// carries the ecobee-reported AuthorizationError when the failure came
+// back with one along with a non-empty error_description, so callers (e.g. a Metrics implementation) can classify
+// failures without matching on error text; see ReauthFailureClass.
+type ReauthError struct {
+	Reason AuthorizationError // empty if the failure wasn't an ecobee authorization error with a description
+	msg    string
+}
+
+func (e *ReauthError) Error() string { return e.msg }
+
+// transient reports whether the reauth failure represented by r and the
+// accompanying HTTP status is worth retrying.
+func (r *reauthResponse) transient(status int) bool {
+	if status/100 == 5 {
+		return true
+	}
+	if r.Err == nil {
+		return false
+	}
+	switch r.Err.Error {
+	case AuthorizationErrorSlowDown, AuthorizationErrorAuthorizationPending:
+		return true
+	}
+	return false
 }
 
 func reauthResponseFromHTTPResponse(resp *http.Response) (*reauthResponse, error) {
@@ -52,48 +95,153 @@ func reauthResponseFromHTTPResponse(resp *http.Response) (*reauthResponse, error
 type authorizingTransport struct {
 	auth      TokenStorer
 	transport http.RoundTripper
-	appID     string
+	cfg       *Config
+
+	// ReauthThreshold overrides defaultReauthThreshold when positive.
+	ReauthThreshold time.Duration
+
+	// metrics receives instrumentation events. Use Client.SetMetrics to
+	// set it; nil falls back to noopMetrics.
+	metrics Metrics
+
+	// sf coalesces concurrent reauth attempts triggered by concurrent
+	// requests so only one of them hits the token endpoint.
+	sf singleflight.Group
+}
+
+func (t *authorizingTransport) m() Metrics {
+	if t.metrics != nil {
+		return t.metrics
+	}
+	return noopMetrics{}
 }
 
 func (t *authorizingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if t.shouldReauth() {
-		if err := t.reauth(); err != nil {
+		t.m().IncReauthAttempt()
+		start := time.Now()
+		err := t.reauth(req.Context())
+		t.m().ObserveReauthLatency(time.Since(start))
+		if err != nil {
+			t.m().IncReauthFailure(err)
 			return nil, err
 		}
+		t.m().IncReauthSuccess()
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", t.auth.AccessToken()))
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil {
+		t.m().ObserveAPICall(resp.StatusCode, time.Since(start))
+	}
+	return resp, err
+}
+
+func (t *authorizingTransport) reauthThreshold() time.Duration {
+	if t.ReauthThreshold > 0 {
+		return t.ReauthThreshold
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", t.auth.AccessToken()))
-	return t.transport.RoundTrip(req)
+	return defaultReauthThreshold
 }
 
 func (t *authorizingTransport) shouldReauth() bool {
-	// TODO(cfunkhouser): make the timeout customizable.
-	return (t.auth.ValidFor() < (time.Second * 15)) || (t.auth.AccessToken() == "")
+	return (t.auth.ValidFor() < t.reauthThreshold()) || (t.auth.AccessToken() == "")
 }
 
-func (t *authorizingTransport) sendReauth(url string) (*reauthResponse, error) {
-	refreshToken, err := t.auth.RefreshToken()
+// sendReauth makes a single refresh_token request against the ecobee token
+// endpoint, returning the parsed response along with the HTTP status it
+// arrived with so callers can decide whether the failure is transient.
+func (t *authorizingTransport) sendReauth(ctx context.Context, refreshToken string) (*reauthResponse, int, error) {
+	v := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {t.cfg.AppID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.tokenURL()+"?"+v.Encode(), nil)
 	if err != nil {
-		log.Fatalf("Unable to get refreshToken for request: %v", err)
+		return nil, 0, err
 	}
-	tokenURL := fmt.Sprintf("%v?grant_type=refresh_token&refresh_token=%v&client_id=%v", url, refreshToken, t.appID)
-	resp, err := http.Post(tokenURL, "", nil)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
-	return reauthResponseFromHTTPResponse(resp)
+	r, err := reauthResponseFromHTTPResponse(resp)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return r, resp.StatusCode, nil
+}
+
+// sendReauthWithBackoff retries sendReauth with exponential backoff and
+// jitter as long as the failure looks transient, e.g. a 5xx from ecobee or
+// the slow_down/authorization_pending errors ecobee uses to rate-limit the
+// token endpoint.
+func (t *authorizingTransport) sendReauthWithBackoff(ctx context.Context, refreshToken string) (*TokenRefreshResponse, error) {
+	backoff := initialReauthBackoff
+	for attempt := 0; ; attempt++ {
+		r, status, err := t.sendReauth(ctx, refreshToken)
+		if err != nil {
+			return nil, err
+		}
+		if r.ok() {
+			return r.Resp, nil
+		}
+		if attempt >= maxReauthAttempts-1 || !r.transient(status) {
+			return nil, r.err()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(jitter(backoff)):
+		}
+		if backoff *= 2; backoff > maxReauthBackoff {
+			backoff = maxReauthBackoff
+		}
+	}
 }
 
-func (t *authorizingTransport) reauth() error {
-	r, err := t.sendReauth(ecobeeTokenURL)
+// jitter returns a random duration in [d/2, 3d/2), to keep concurrent
+// clients from retrying in lockstep.
+func jitter(d time.Duration) time.Duration {
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+func (t *authorizingTransport) reauth(ctx context.Context) error {
+	refreshToken, err := t.auth.RefreshToken()
 	if err != nil {
-		return err
+		return fmt.Errorf("unable to get refresh token: %w", err)
 	}
-	if !r.ok() {
-		return r.err()
+
+	// The actual refresh runs on a context detached from ctx's
+	// cancellation: whichever caller's sf.Do happens to become the
+	// singleflight leader shouldn't have its context govern a retry loop
+	// that other, unrelated callers are also waiting on. Update/
+	// NotifyRefreshError happen inside the goroutine itself, not after
+	// the select below, so the result is always persisted once the
+	// shared refresh completes even if every caller's own ctx has
+	// already expired and stopped waiting on it.
+	done := make(chan struct{})
+	var callErr error
+	go func() {
+		defer close(done)
+		v, err, _ := t.sf.Do(refreshToken, func() (interface{}, error) {
+			return t.sendReauthWithBackoff(context.WithoutCancel(ctx), refreshToken)
+		})
+		if err != nil {
+			t.auth.NotifyRefreshError(err)
+			callErr = err
+			return
+		}
+		callErr = t.auth.Update(v.(*TokenRefreshResponse))
+	}()
+
+	select {
+	case <-done:
+		return callErr
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	t.auth.Update(r.Resp)
-	return nil
 }
 
 // Client for the ecobee API.
@@ -101,15 +249,34 @@ type Client struct {
 	http.Client
 }
 
-// New egobee client.
-func New(appID string, ts TokenStorer) *Client {
+// New egobee client. cfg supplies the application ID and token endpoint
+// used to keep ts refreshed.
+func New(cfg *Config, ts TokenStorer) *Client {
 	return &Client{
 		Client: http.Client{
 			Transport: &authorizingTransport{
 				auth:      ts,
 				transport: http.DefaultTransport,
-				appID:     appID,
+				cfg:       cfg,
 			},
 		},
 	}
 }
+
+// SetReauthThreshold overrides how much validity must remain on the access
+// token before c proactively refreshes it. The zero value keeps the
+// default of 15 seconds. It has no effect if c wasn't built with New.
+func (c *Client) SetReauthThreshold(d time.Duration) {
+	if t, ok := c.Transport.(*authorizingTransport); ok {
+		t.ReauthThreshold = d
+	}
+}
+
+// SetMetrics gives c a Metrics implementation to report reauth and API
+// call instrumentation to, replacing the default no-op. It has no effect
+// if c wasn't built with New.
+func (c *Client) SetMetrics(m Metrics) {
+	if t, ok := c.Transport.(*authorizingTransport); ok {
+		t.metrics = m
+	}
+}
@@ -1,8 +1,11 @@
 package egobee
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 )
@@ -11,26 +14,37 @@ type fakeTokenStore struct {
 	access  string
 	refresh string
 	vf      time.Duration
+
+	// updates, if non-nil, receives every TokenRefreshResponse passed to
+	// Update.
+	updates chan *TokenRefreshResponse
 }
 
 func (s *fakeTokenStore) AccessToken() string {
 	return s.access
 }
 
-func (s *fakeTokenStore) RefreshToken() string {
-	return s.refresh
+func (s *fakeTokenStore) RefreshToken() (string, error) {
+	return s.refresh, nil
 }
 
 func (s *fakeTokenStore) ValidFor() time.Duration {
 	return s.vf
 }
 
-func (s *fakeTokenStore) Update(r *TokenRefreshResponse) {}
+func (s *fakeTokenStore) Update(r *TokenRefreshResponse) error {
+	if s.updates != nil {
+		s.updates <- r
+	}
+	return nil
+}
+
+func (s *fakeTokenStore) NotifyRefreshError(err error) {}
 
 func TestAuthorizingTransport(t *testing.T) {
 	clientForTest := http.Client{
 		Transport: &authorizingTransport{
-			auth:      &fakeTokenStore{"thisisanaccesstoken", "thisisarefreshtoken", time.Minute * 30},
+			auth:      &fakeTokenStore{access: "thisisanaccesstoken", refresh: "thisisarefreshtoken", vf: time.Minute * 30},
 			transport: http.DefaultTransport,
 		},
 	}
@@ -93,30 +107,114 @@ func TestReauthResponse_OK(t *testing.T) {
 	}
 }
 
+// TestAuthorizingTransport_ReauthDoesNotInheritLeaderContext exercises the
+// case where two callers with the same refresh token race into reauth():
+// whichever becomes the singleflight leader must not have its context
+// cancel the shared refresh loop out from under the other caller.
+func TestAuthorizingTransport_ReauthDoesNotInheritLeaderContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		json.NewEncoder(w).Encode(TokenRefreshResponse{AccessToken: "new-access", RefreshToken: "shared-token"})
+	}))
+	defer server.Close()
+
+	tr := &authorizingTransport{
+		auth: &fakeTokenStore{refresh: "shared-token"},
+		cfg:  &Config{TokenURL: server.URL},
+	}
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	leaderErrCh := make(chan error, 1)
+	go func() { leaderErrCh <- tr.reauth(leaderCtx) }()
+
+	// Give the leader a head start so it (rather than the follower) is
+	// the one whose sf.Do call actually runs sendReauthWithBackoff.
+	time.Sleep(5 * time.Millisecond)
+	followerErrCh := make(chan error, 1)
+	go func() { followerErrCh <- tr.reauth(context.Background()) }()
+
+	if err := <-leaderErrCh; err == nil {
+		t.Errorf("leader reauth: got nil error, want its context to have expired")
+	}
+
+	close(release)
+
+	if err := <-followerErrCh; err != nil {
+		t.Errorf("follower reauth: got %v, want nil since its own context was never cancelled", err)
+	}
+}
+
+// TestAuthorizingTransport_ReauthPersistsResultEvenIfCallersGiveUp covers
+// the case where every caller's own context expires before the detached
+// shared refresh finishes: the refreshed token must still be persisted
+// once it arrives, not dropped because no caller was left waiting on it.
+func TestAuthorizingTransport_ReauthPersistsResultEvenIfCallersGiveUp(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		json.NewEncoder(w).Encode(TokenRefreshResponse{AccessToken: "new-access", RefreshToken: "new-refresh"})
+	}))
+	defer server.Close()
+
+	updates := make(chan *TokenRefreshResponse, 2)
+	tr := &authorizingTransport{
+		auth: &fakeTokenStore{refresh: "shared-token", updates: updates},
+		cfg:  &Config{TokenURL: server.URL},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if err := tr.reauth(ctx); err == nil {
+				t.Error("reauth: got nil error, want its context to have expired before the server responded")
+			}
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+
+	select {
+	case got := <-updates:
+		if got.AccessToken != "new-access" {
+			t.Errorf("Update called with AccessToken %q, want %q", got.AccessToken, "new-access")
+		}
+	case <-time.After(time.Second):
+		t.Error("Update was never called even though the server eventually responded successfully")
+	}
+}
+
 func TestAuthorizingTransport_ShouldReauth(t *testing.T) {
 	for _, tt := range []struct {
 		name string
-		ts   TokenStore
+		ts   TokenStorer
 		want bool
 	}{
 		{
 			name: "shouldn't reauth",
-			ts:   &fakeTokenStore{"foo", "bar", time.Minute * 30},
+			ts:   &fakeTokenStore{access: "foo", refresh: "bar", vf: time.Minute * 30},
 			want: false,
 		},
 		{
 			name: "reauth for time",
-			ts:   &fakeTokenStore{"foo", "bar", time.Second},
+			ts:   &fakeTokenStore{access: "foo", refresh: "bar", vf: time.Second},
 			want: true,
 		},
 		{
 			name: "reauth for token",
-			ts:   &fakeTokenStore{"", "", time.Minute * 30},
+			ts:   &fakeTokenStore{vf: time.Minute * 30},
 			want: true,
 		},
 		{
 			name: "reauth for both", // just for good measure.
-			ts:   &fakeTokenStore{"", "", time.Second},
+			ts:   &fakeTokenStore{vf: time.Second},
 			want: true,
 		},
 	} {
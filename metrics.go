@@ -0,0 +1,62 @@
+package egobee
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Metrics receives instrumentation events from authorizingTransport, so
+// applications can alert on refresh failures or watch API latency without
+// scraping logs. A Client built with New uses a no-op implementation until
+// SetMetrics is called; see the prometheus subpackage for a ready-made
+// Prometheus adapter.
+type Metrics interface {
+	// IncReauthAttempt is called each time a token refresh is attempted,
+	// before the request is sent.
+	IncReauthAttempt()
+
+	// IncReauthSuccess is called after a token refresh succeeds.
+	IncReauthSuccess()
+
+	// IncReauthFailure is called after a token refresh fails outright,
+	// i.e. once retries are exhausted.
+	IncReauthFailure(err error)
+
+	// ObserveReauthLatency is called with the total time spent
+	// refreshing the token, including any retries.
+	ObserveReauthLatency(d time.Duration)
+
+	// ObserveAPICall is called after every request sent through the
+	// Client that gets an HTTP response, with its HTTP status and latency.
+	// Requests to the token endpoint made while refreshing, and requests
+	// that fail without a response, are not reported.
+	ObserveAPICall(status int, d time.Duration)
+}
+
+// noopMetrics discards every event. It's the default Metrics for a Client
+// built with New.
+type noopMetrics struct{}
+
+func (noopMetrics) IncReauthAttempt()                  {}
+func (noopMetrics) IncReauthSuccess()                  {}
+func (noopMetrics) IncReauthFailure(error)             {}
+func (noopMetrics) ObserveReauthLatency(time.Duration) {}
+func (noopMetrics) ObserveAPICall(int, time.Duration)  {}
+
+// ReauthFailureClass buckets a reauth error into a small, fixed set of
+// labels so a Metrics implementation can classify IncReauthFailure calls
+// without labeling on raw, unbounded error text: the ecobee
+// AuthorizationError when err is a *ReauthError that carries one,
+// "context" for cancellation/deadline errors, and "other" for everything
+// else.
+func ReauthFailureClass(err error) string {
+	var re *ReauthError
+	if errors.As(err, &re) && re.Reason != "" {
+		return string(re.Reason)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return "context"
+	}
+	return "other"
+}
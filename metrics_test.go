@@ -0,0 +1,46 @@
+package egobee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReauthFailureClass(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "ecobee authorization error",
+			err:  &ReauthError{Reason: AuthorizationErrorInvalidGrant, msg: "unable to re-authenticate: invalid_grant: the refresh token is invalid"},
+			want: string(AuthorizationErrorInvalidGrant),
+		},
+		{
+			name: "ReauthError with no Reason",
+			err:  &ReauthError{msg: "unable to re-authenticate for unknown reasons"},
+			want: "other",
+		},
+		{
+			name: "wrapped context deadline",
+			err:  fmt.Errorf("reauth: %w", context.DeadlineExceeded),
+			want: "context",
+		},
+		{
+			name: "context canceled",
+			err:  context.Canceled,
+			want: "context",
+		},
+		{
+			name: "unrelated error",
+			err:  errors.New("connection refused"),
+			want: "other",
+		},
+	} {
+		if got := ReauthFailureClass(tt.err); got != tt.want {
+			t.Errorf("%v: ReauthFailureClass() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package egobee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ecobeeAuthURL is the default ecobee authorization endpoint, used by
+// Config when AuthURL is unset.
+const ecobeeAuthURL = "https://api.ecobee.com/authorize"
+
+// Config holds the application settings needed to run the ecobee OAuth2
+// flows: authorization-code (AuthCodeURL/Exchange) and PIN-based device
+// authorization (PIN/PollForToken).
+//
+// See https://www.ecobee.com/home/developer/api/documentation/v1/auth/auth-intro.shtml
+type Config struct {
+	// AppID is the ecobee-issued application (API) key.
+	AppID string
+
+	// Scope requested during authorization.
+	Scope Scope
+
+	// RedirectURL receives the authorization code once the user grants
+	// access. Only used by the authorization-code flow.
+	RedirectURL string
+
+	// AuthURL is the ecobee authorization endpoint. If empty, the default
+	// production endpoint is used.
+	AuthURL string
+
+	// TokenURL is the ecobee token endpoint. If empty, the default
+	// production endpoint is used.
+	TokenURL string
+}
+
+func (c *Config) authURL() string {
+	if c.AuthURL != "" {
+		return c.AuthURL
+	}
+	return ecobeeAuthURL
+}
+
+func (c *Config) tokenURL() string {
+	if c.TokenURL != "" {
+		return c.TokenURL
+	}
+	return ecobeeTokenURL
+}
+
+// AuthCodeURL builds the URL to send a user to in order to begin the
+// authorization-code flow. state is echoed back on the redirect and should
+// be used by callers to protect against CSRF.
+func (c *Config) AuthCodeURL(state string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.AppID},
+		"redirect_uri":  {c.RedirectURL},
+	}
+	if c.Scope != "" {
+		v.Set("scope", string(c.Scope))
+	}
+	if state != "" {
+		v.Set("state", state)
+	}
+	return c.authURL() + "?" + v.Encode()
+}
+
+// Exchange trades an authorization code obtained via AuthCodeURL for an
+// access/refresh token pair. The result may be passed directly to
+// NewMemoryTokenStore or NewPersistentTokenStore.
+func (c *Config) Exchange(ctx context.Context, code string) (*TokenRefreshResponse, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"client_id":    {c.AppID},
+		"redirect_uri": {c.RedirectURL},
+	})
+}
+
+// PINResponse is returned by the ecobee PIN (device authorization)
+// endpoint. Show EcobeePin to the user and have them enter it under My
+// Apps in the ecobee consumer portal, then pass Code to PollForToken to
+// complete authorization once they've done so.
+type PINResponse struct {
+	EcobeePin string        `json:"ecobeePin"`
+	Code      string        `json:"code"`
+	Scope     Scope         `json:"scope"`
+	ExpiresIn TokenDuration `json:"expires_in"`
+	Interval  int           `json:"interval"`
+}
+
+// PIN begins the PIN (device authorization) flow, returning the PIN to
+// display to the user and the code to poll for with PollForToken.
+func (c *Config) PIN(ctx context.Context) (*PINResponse, error) {
+	v := url.Values{
+		"response_type": {"ecobeePin"},
+		"client_id":     {c.AppID},
+	}
+	if c.Scope != "" {
+		v.Set("scope", string(c.Scope))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.authURL()+"?"+v.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if (resp.StatusCode / 100) != 2 {
+		e := &AuthorizationErrorResponse{}
+		if err := e.Populate(resp.Body); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to obtain PIN: %v: %v", e.Error, e.Description)
+	}
+	p := &PINResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PollForToken polls the token endpoint with the code returned by PIN
+// until the user has authorized the app, the code expires, or ctx is
+// cancelled. It handles the authorization_pending and slow_down responses
+// ecobee uses to implement the PIN flow's polling backoff.
+func (c *Config) PollForToken(ctx context.Context, code string) (*TokenRefreshResponse, error) {
+	v := url.Values{
+		"grant_type": {"ecobeePin"},
+		"code":       {code},
+		"client_id":  {c.AppID},
+	}
+	interval := 5 * time.Second
+	for {
+		r, err := c.sendTokenRequest(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		if r.ok() {
+			return r.Resp, nil
+		}
+		switch r.Err.Error {
+		case AuthorizationErrorSlowDown:
+			interval += 5 * time.Second
+			fallthrough
+		case AuthorizationErrorAuthorizationPending:
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(interval):
+			}
+		default:
+			return nil, r.err()
+		}
+	}
+}
+
+// requestToken sends v to the token endpoint and unwraps the result into a
+// TokenRefreshResponse, or an error describing why ecobee refused it.
+func (c *Config) requestToken(ctx context.Context, v url.Values) (*TokenRefreshResponse, error) {
+	r, err := c.sendTokenRequest(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	if !r.ok() {
+		return nil, r.err()
+	}
+	return r.Resp, nil
+}
+
+func (c *Config) sendTokenRequest(ctx context.Context, v url.Values) (*reauthResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL()+"?"+v.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return reauthResponseFromHTTPResponse(resp)
+}
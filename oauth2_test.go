@@ -0,0 +1,126 @@
+package egobee
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConfig_AuthCodeURL(t *testing.T) {
+	cfg := &Config{AppID: "app123", RedirectURL: "https://example.com/callback", Scope: ScopeSmartRead}
+	got := cfg.AuthCodeURL("xyz")
+	want := "https://api.ecobee.com/authorize?client_id=app123&redirect_uri=https%3A%2F%2Fexample.com%2Fcallback&response_type=code&scope=smartRead&state=xyz"
+	if got != want {
+		t.Errorf("AuthCodeURL: got %q, want %q", got, want)
+	}
+}
+
+func TestConfig_Exchange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("grant_type"); got != "authorization_code" {
+			t.Errorf("grant_type: got %q, want %q", got, "authorization_code")
+		}
+		if got := r.URL.Query().Get("code"); got != "authcode" {
+			t.Errorf("code: got %q, want %q", got, "authcode")
+		}
+		json.NewEncoder(w).Encode(TokenRefreshResponse{AccessToken: "acc", RefreshToken: "ref"})
+	}))
+	defer server.Close()
+
+	cfg := &Config{AppID: "app", TokenURL: server.URL}
+	got, err := cfg.Exchange(context.Background(), "authcode")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if got.AccessToken != "acc" {
+		t.Errorf("AccessToken: got %q, want %q", got.AccessToken, "acc")
+	}
+}
+
+func TestConfig_PIN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PINResponse{EcobeePin: "ABCD-EFGH", Code: "thecode", Interval: 5})
+	}))
+	defer server.Close()
+
+	cfg := &Config{AppID: "app", AuthURL: server.URL}
+	got, err := cfg.PIN(context.Background())
+	if err != nil {
+		t.Fatalf("PIN: %v", err)
+	}
+	if got.EcobeePin != "ABCD-EFGH" || got.Code != "thecode" {
+		t.Errorf("PIN: got %+v, want EcobeePin %q and Code %q", got, "ABCD-EFGH", "thecode")
+	}
+}
+
+func tokenErrorHandler(e AuthorizationError) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AuthorizationErrorResponse{Error: e, Description: "test"})
+	}
+}
+
+func TestConfig_PollForToken_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TokenRefreshResponse{AccessToken: "acc", RefreshToken: "ref"})
+	}))
+	defer server.Close()
+
+	cfg := &Config{AppID: "app", TokenURL: server.URL}
+	got, err := cfg.PollForToken(context.Background(), "code")
+	if err != nil {
+		t.Fatalf("PollForToken: %v", err)
+	}
+	if got.AccessToken != "acc" {
+		t.Errorf("AccessToken: got %q, want %q", got.AccessToken, "acc")
+	}
+}
+
+func TestConfig_PollForToken_TerminalErrorStopsImmediately(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		tokenErrorHandler(AuthorizationErrorAccessDenied)(w, r)
+	}))
+	defer server.Close()
+
+	cfg := &Config{AppID: "app", TokenURL: server.URL}
+	_, err := cfg.PollForToken(context.Background(), "code")
+	if err == nil {
+		t.Fatal("PollForToken: got nil error, want access_denied to be terminal")
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want exactly 1 (a terminal error shouldn't be retried)", requests)
+	}
+}
+
+// TestConfig_PollForToken_PendingAndSlowDownAreRetried covers the
+// slow_down/authorization_pending branches of PollForToken's polling loop:
+// both are treated as transient (the poll keeps going, rather than
+// surfacing them as the final error) and only stop once ctx is done.
+func TestConfig_PollForToken_PendingAndSlowDownAreRetried(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  AuthorizationError
+	}{
+		{"authorization_pending", AuthorizationErrorAuthorizationPending},
+		{"slow_down", AuthorizationErrorSlowDown},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tokenErrorHandler(tt.err))
+			defer server.Close()
+
+			cfg := &Config{AppID: "app", TokenURL: server.URL}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			_, err := cfg.PollForToken(ctx, "code")
+			if err != context.DeadlineExceeded {
+				t.Errorf("PollForToken: got %v, want context.DeadlineExceeded (a %v response shouldn't be treated as terminal)", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package prometheus provides a Prometheus-backed implementation of
+// egobee.Metrics.
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jamesmulcahy/egobee"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements egobee.Metrics using Prometheus client_golang
+// collectors. The zero value is not usable; construct one with NewMetrics
+// and pass it to Client.SetMetrics.
+type Metrics struct {
+	reauthAttempts prometheus.Counter
+	reauthSuccess  prometheus.Counter
+	reauthFailure  *prometheus.CounterVec
+	reauthLatency  prometheus.Histogram
+	apiCallLatency *prometheus.HistogramVec
+}
+
+// NewMetrics creates a Metrics and registers its collectors with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		reauthAttempts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "egobee_reauth_attempts_total",
+			Help: "Number of token refreshes attempted against the ecobee token endpoint.",
+		}),
+		reauthSuccess: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "egobee_reauth_success_total",
+			Help: "Number of token refreshes that succeeded.",
+		}),
+		reauthFailure: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "egobee_reauth_failure_total",
+			Help: "Number of token refreshes that failed, labeled by egobee.ReauthFailureClass.",
+		}, []string{"class"}),
+		reauthLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "egobee_reauth_latency_seconds",
+			Help: "Time spent refreshing the token, including retries.",
+		}),
+		apiCallLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "egobee_api_call_latency_seconds",
+			Help: "Latency of calls to the ecobee API, labeled by response status.",
+		}, []string{"status"}),
+	}
+	reg.MustRegister(m.reauthAttempts, m.reauthSuccess, m.reauthFailure, m.reauthLatency, m.apiCallLatency)
+	return m
+}
+
+// IncReauthAttempt implements egobee.Metrics.
+func (m *Metrics) IncReauthAttempt() { m.reauthAttempts.Inc() }
+
+// IncReauthSuccess implements egobee.Metrics.
+func (m *Metrics) IncReauthSuccess() { m.reauthSuccess.Inc() }
+
+// IncReauthFailure implements egobee.Metrics. err is labeled by
+// egobee.ReauthFailureClass rather than its raw text, keeping the
+// egobee_reauth_failure_total series bounded in cardinality.
+func (m *Metrics) IncReauthFailure(err error) {
+	m.reauthFailure.WithLabelValues(egobee.ReauthFailureClass(err)).Inc()
+}
+
+// ObserveReauthLatency implements egobee.Metrics.
+func (m *Metrics) ObserveReauthLatency(d time.Duration) {
+	m.reauthLatency.Observe(d.Seconds())
+}
+
+// ObserveAPICall implements egobee.Metrics.
+func (m *Metrics) ObserveAPICall(status int, d time.Duration) {
+	m.apiCallLatency.WithLabelValues(strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+var _ egobee.Metrics = (*Metrics)(nil)
@@ -0,0 +1,50 @@
+package prometheus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jamesmulcahy/egobee"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetrics_IncReauthFailure_LabelsByClassNotRawText(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	// Two distinct error values that classify the same way should
+	// collapse onto a single low-cardinality series, rather than each
+	// raw error string getting its own.
+	m.IncReauthFailure(errors.New("dial tcp: connection refused"))
+	m.IncReauthFailure(errors.New("dial tcp 10.0.0.1:443: i/o timeout"))
+	m.IncReauthFailure(&egobee.ReauthError{})
+
+	metricFamily := &dto.Metric{}
+	if err := m.reauthFailure.WithLabelValues("other").Write(metricFamily); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := metricFamily.GetCounter().GetValue(); got != 3 {
+		t.Errorf(`counter for label "other": got %v, want 3`, got)
+	}
+}
+
+func TestMetrics_IncReauthFailure_SeparatesAuthorizationErrors(t *testing.T) {
+	m := NewMetrics(prometheus.NewRegistry())
+
+	m.IncReauthFailure(&egobee.ReauthError{Reason: egobee.AuthorizationErrorInvalidGrant})
+	m.IncReauthFailure(&egobee.ReauthError{Reason: egobee.AuthorizationErrorAccessDenied})
+
+	var got int
+	for _, label := range []string{string(egobee.AuthorizationErrorInvalidGrant), string(egobee.AuthorizationErrorAccessDenied)} {
+		metricFamily := &dto.Metric{}
+		if err := m.reauthFailure.WithLabelValues(label).Write(metricFamily); err != nil {
+			t.Fatalf("Write(%q): %v", label, err)
+		}
+		if metricFamily.GetCounter().GetValue() == 1 {
+			got++
+		}
+	}
+	if got != 2 {
+		t.Errorf("got %d of 2 expected AuthorizationError labels with count 1", got)
+	}
+}
@@ -0,0 +1,247 @@
+package egobee
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Backend persists the raw bytes backing a TokenStorer. Implementations
+// need not know anything about tokens; they just move bytes to and from
+// wherever they're kept.
+type Backend interface {
+	// Load returns the most recently saved bytes. If none have been saved
+	// yet it returns an error, or empty data in the case of FileBackend,
+	// which creates an empty file on first Load.
+	Load() ([]byte, error)
+
+	// Save persists b, replacing whatever was previously saved.
+	Save(b []byte) error
+}
+
+// changeChecker is implemented by Backends which can cheaply report
+// whether their contents may have changed since the last Load, letting
+// persistentStore skip a reload on every read.
+type changeChecker interface {
+	Changed() (bool, error)
+}
+
+// FileBackend persists to a single file on disk. Saves are atomic: the new
+// contents are written to a temp file alongside Path, fsynced, and renamed
+// into place. An flock on Path guards both Load and Save against other
+// processes racing on the same file.
+type FileBackend struct {
+	Path string
+	Mode os.FileMode
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewFileBackend returns a Backend which reads and writes path, creating
+// it with the given permissions if it doesn't yet exist.
+func NewFileBackend(path string, mode os.FileMode) *FileBackend {
+	return &FileBackend{Path: path, Mode: mode}
+}
+
+func (b *FileBackend) mode() os.FileMode {
+	if b.Mode == 0 {
+		return 0600
+	}
+	return b.Mode
+}
+
+func flock(f *os.File) (unlock func(), err error) {
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, fmt.Errorf("locking %v: %w", f.Name(), err)
+	}
+	return func() { syscall.Flock(int(f.Fd()), syscall.LOCK_UN) }, nil
+}
+
+// Load implements Backend.
+func (b *FileBackend) Load() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lf, err := os.OpenFile(b.Path, os.O_RDONLY|os.O_CREATE, b.mode())
+	if err != nil {
+		return nil, err
+	}
+	defer lf.Close()
+
+	unlock, err := flock(lf)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// A concurrent Save may have renamed a new file into place between
+	// lf's open above and the flock acquiring the lock, leaving lf
+	// pointing at the old, now-unlinked inode. Re-open by path under the
+	// lock so a Save that finished first is actually visible here.
+	f, err := os.Open(b.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := f.Stat(); err == nil {
+		b.modTime = fi.ModTime()
+	}
+	return data, nil
+}
+
+// Save implements Backend.
+func (b *FileBackend) Save(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lf, err := os.OpenFile(b.Path, os.O_RDONLY|os.O_CREATE, b.mode())
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+
+	unlock, err := flock(lf)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tmp := b.Path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, b.mode())
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.Path); err != nil {
+		return err
+	}
+
+	if fi, err := os.Stat(b.Path); err == nil {
+		b.modTime = fi.ModTime()
+	}
+	return nil
+}
+
+// Changed implements changeChecker.
+func (b *FileBackend) Changed() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fi, err := os.Stat(b.Path)
+	if err != nil {
+		return false, err
+	}
+	return fi.ModTime().After(b.modTime), nil
+}
+
+// KeyringBackend persists to the OS credential store (macOS Keychain,
+// Windows Credential Manager, the Secret Service on Linux, etc.) via
+// go-keyring.
+type KeyringBackend struct {
+	Service string
+	User    string
+}
+
+// NewKeyringBackend returns a Backend which stores under user in the OS
+// credential store's service namespace.
+func NewKeyringBackend(service, user string) *KeyringBackend {
+	return &KeyringBackend{Service: service, User: user}
+}
+
+// Load implements Backend.
+func (b *KeyringBackend) Load() ([]byte, error) {
+	s, err := keyring.Get(b.Service, b.User)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// Save implements Backend.
+func (b *KeyringBackend) Save(data []byte) error {
+	return keyring.Set(b.Service, b.User, string(data))
+}
+
+// EncryptedFileBackend wraps a FileBackend, encrypting its contents at
+// rest with AES-GCM under key, which must be 16, 24, or 32 bytes to select
+// AES-128/192/256.
+type EncryptedFileBackend struct {
+	inner Backend
+	key   []byte
+}
+
+// NewEncryptedFileBackend returns a Backend which stores AES-GCM encrypted
+// data at path.
+func NewEncryptedFileBackend(path string, mode os.FileMode, key []byte) *EncryptedFileBackend {
+	return &EncryptedFileBackend{inner: NewFileBackend(path, mode), key: key}
+}
+
+func (b *EncryptedFileBackend) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(b.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Load implements Backend.
+func (b *EncryptedFileBackend) Load() ([]byte, error) {
+	ciphertext, err := b.inner.Load()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := b.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("egobee: encrypted token data is truncated")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// Save implements Backend.
+func (b *EncryptedFileBackend) Save(data []byte) error {
+	gcm, err := b.gcm()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return b.inner.Save(gcm.Seal(nonce, nonce, data, nil))
+}
+
+// Changed implements changeChecker if the wrapped Backend does.
+func (b *EncryptedFileBackend) Changed() (bool, error) {
+	if cc, ok := b.inner.(changeChecker); ok {
+		return cc.Changed()
+	}
+	return true, nil
+}
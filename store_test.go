@@ -0,0 +1,158 @@
+package egobee
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestFileBackend_SaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	b := NewFileBackend(path, 0600)
+
+	if err := b.Save([]byte("v1")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := b.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(got) != "v1" {
+		t.Errorf("Load after Save(%q): got %q", "v1", got)
+	}
+
+	if err := b.Save([]byte("v2")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err = b.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(got) != "v2" {
+		t.Errorf("Load after second Save: got %q, want %q", got, "v2")
+	}
+}
+
+// TestFileBackend_ConcurrentLoadSeesCommittedSaves guards against Load
+// reading from a file descriptor it opened before a racing Save's rename,
+// which used to let Load return stale (or the pre-rename, now-unlinked)
+// bytes even after the Save it raced against had already returned.
+func TestFileBackend_ConcurrentLoadSeesCommittedSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	b := NewFileBackend(path, 0600)
+	if err := b.Save([]byte("v0")); err != nil {
+		t.Fatalf("seed Save: %v", err)
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+
+	for i := 1; i <= n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.Save([]byte(fmt.Sprintf("v%d", i))); err != nil {
+				errs <- fmt.Errorf("Save(v%d): %v", i, err)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := b.Load()
+			if err != nil {
+				errs <- fmt.Errorf("Load: %v", err)
+				return
+			}
+			if len(data) == 0 || data[0] != 'v' {
+				errs <- fmt.Errorf("Load returned malformed data %q", data)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	final, err := b.Load()
+	if err != nil {
+		t.Fatalf("final Load: %v", err)
+	}
+	if len(final) == 0 || final[0] != 'v' {
+		t.Errorf("final Load: got %q, want a v<n> value", final)
+	}
+}
+
+func TestFileBackend_Changed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	b := NewFileBackend(path, 0600)
+	if err := b.Save([]byte("v1")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := b.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if changed, err := b.Changed(); err != nil || changed {
+		t.Errorf("Changed() right after Load: got (%v, %v), want (false, nil)", changed, err)
+	}
+
+	// A write through a second Backend instance on the same path (e.g. a
+	// second process sharing the file) isn't reflected in b's in-memory
+	// modTime, so Changed should report it.
+	other := NewFileBackend(path, 0600)
+	if err := other.Save([]byte("v2")); err != nil {
+		t.Fatalf("Save via second backend: %v", err)
+	}
+	if changed, err := b.Changed(); err != nil || !changed {
+		t.Errorf("Changed() after an external Save: got (%v, %v), want (true, nil)", changed, err)
+	}
+}
+
+func TestEncryptedFileBackend_SaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	key := make([]byte, 32)
+	b := NewEncryptedFileBackend(path, 0600, key)
+
+	if err := b.Save([]byte("super-secret")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := b.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(got) != "super-secret" {
+		t.Errorf("Load: got %q, want %q", got, "super-secret")
+	}
+
+	onDisk, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %v directly: %v", path, err)
+	}
+	if string(onDisk) == "super-secret" {
+		t.Errorf("contents on disk are plaintext, want them encrypted")
+	}
+}
+
+func TestKeyringBackend_SaveLoadRoundTrip(t *testing.T) {
+	keyring.MockInit()
+	b := NewKeyringBackend("egobee-test", "user")
+
+	if err := b.Save([]byte("v1")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := b.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(got) != "v1" {
+		t.Errorf("Load: got %q, want %q", got, "v1")
+	}
+}
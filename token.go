@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"io/ioutil"
-	"os"
 	"regexp"
 	"sync"
 	"time"
@@ -156,6 +154,48 @@ type TokenStorer interface {
 	// Update the TokenStorer with the contents of the response. This mutates the
 	// access and refresh tokens.
 	Update(*TokenRefreshResponse) error
+
+	// NotifyRefreshError informs the store's observers, if any, that an
+	// attempt to refresh the token failed. It does not mutate state.
+	NotifyRefreshError(err error)
+}
+
+// TokenObserver reacts to token lifecycle events on a TokenStorer, so
+// applications can do things like alert on refresh failures without
+// polling ValidFor().
+type TokenObserver interface {
+	// OnRefresh is called after the store's token is successfully
+	// refreshed, with the token data from before and after the refresh.
+	OnRefresh(old, new *TokenRefreshResponse)
+
+	// OnRefreshError is called when an attempt to refresh the token
+	// fails.
+	OnRefreshError(err error)
+
+	// OnLoad is called after the store loads token data it didn't
+	// already have in memory, e.g. from a persistent Backend.
+	OnLoad(*TokenRefreshResponse)
+}
+
+// notifyRefresh calls OnRefresh on each observer.
+func notifyRefresh(observers []TokenObserver, old, new *TokenRefreshResponse) {
+	for _, o := range observers {
+		o.OnRefresh(old, new)
+	}
+}
+
+// notifyRefreshError calls OnRefreshError on each observer.
+func notifyRefreshError(observers []TokenObserver, err error) {
+	for _, o := range observers {
+		o.OnRefreshError(err)
+	}
+}
+
+// notifyLoad calls OnLoad on each observer.
+func notifyLoad(observers []TokenObserver, r *TokenRefreshResponse) {
+	for _, o := range observers {
+		o.OnLoad(r)
+	}
 }
 
 // memoryStore implements tokenStore backed only by memory.
@@ -164,6 +204,9 @@ type memoryStore struct {
 	accessToken  string
 	refreshToken string
 	validUntil   time.Time
+	loaded       bool
+
+	observers []TokenObserver
 }
 
 func (s *memoryStore) AccessToken() string {
@@ -186,17 +229,35 @@ func (s *memoryStore) ValidFor() time.Duration {
 
 func (s *memoryStore) Update(r *TokenRefreshResponse) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	old := &TokenRefreshResponse{
+		AccessToken:  s.accessToken,
+		RefreshToken: s.refreshToken,
+	}
+	wasLoaded := s.loaded
+
 	s.accessToken = r.AccessToken
 	s.refreshToken = r.RefreshToken
 	s.validUntil = generateValidUntil(r)
+	s.loaded = true
+	s.mu.Unlock()
 
+	if wasLoaded {
+		notifyRefresh(s.observers, old, r)
+	} else {
+		notifyLoad(s.observers, r)
+	}
 	return nil
 }
 
-// NewMemoryTokenStore is a TokenStorer with no persistence.
-func NewMemoryTokenStore(r *TokenRefreshResponse) TokenStorer {
-	s := &memoryStore{}
+func (s *memoryStore) NotifyRefreshError(err error) {
+	notifyRefreshError(s.observers, err)
+}
+
+// NewMemoryTokenStore is a TokenStorer with no persistence. Any observers
+// are notified of the initial token via OnLoad, and of subsequent
+// refreshes via OnRefresh/OnRefreshError.
+func NewMemoryTokenStore(r *TokenRefreshResponse, observers ...TokenObserver) TokenStorer {
+	s := &memoryStore{observers: observers}
 	s.Update(r)
 	return s
 }
@@ -208,36 +269,85 @@ type persistentStoreData struct {
 	ValidUntilData   time.Time `json:"validUntil"`
 }
 
-// persistentStore implements tokenStore backed by disk.
+// persistentStore implements TokenStorer atop a Backend. Reads are served
+// from the in-memory copy of persistentStoreData; the Backend is only
+// re-read when it reports (via changeChecker) that its contents may have
+// changed since the last Load, so hot-path reads don't re-read the
+// Backend (with a FileBackend, each read still costs one stat of the file).
 type persistentStore struct {
-	mu sync.RWMutex // protects the following members
+	mu      sync.RWMutex // protects the following members
+	backend Backend
+	loaded  bool
 	persistentStoreData
+
+	observers []TokenObserver
 }
 
-func (s *persistentStore) AccessToken() string {
-	err := s.getPersistentTokenData()
+// refresh reloads persistentStoreData from the backend if it's never been
+// loaded, or reports having changed since the last load.
+func (s *persistentStore) refresh() error {
+	s.mu.Lock()
+
+	if s.loaded {
+		cc, ok := s.backend.(changeChecker)
+		if !ok {
+			s.mu.Unlock()
+			return nil
+		}
+		if changed, err := cc.Changed(); err == nil && !changed {
+			s.mu.Unlock()
+			return nil
+		}
+	}
+
+	raw, err := s.backend.Load()
 	if err != nil {
-		return ""
+		s.mu.Unlock()
+		return err
 	}
+	if err := json.Unmarshal(raw, &s.persistentStoreData); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.loaded = true
+	loadedData := s.toTokenRefreshResponse()
+	s.mu.Unlock()
 
+	notifyLoad(s.observers, loadedData)
+	return nil
+}
+
+// toTokenRefreshResponse reconstructs a TokenRefreshResponse from the
+// store's persisted fields, for the benefit of TokenObservers. Callers
+// must hold s.mu.
+func (s *persistentStore) toTokenRefreshResponse() *TokenRefreshResponse {
+	return &TokenRefreshResponse{
+		AccessToken:  s.AccessTokenData,
+		RefreshToken: s.RefreshTokenData,
+		ExpiresIn:    TokenDuration{Duration: time.Until(s.ValidUntilData)},
+	}
+}
+
+func (s *persistentStore) AccessToken() string {
+	if err := s.refresh(); err != nil {
+		return ""
+	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.AccessTokenData
 }
 
 func (s *persistentStore) RefreshToken() (string, error) {
-	err := s.getPersistentTokenData()
-	if err != nil {
+	if err := s.refresh(); err != nil {
 		return "", err
 	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.RefreshTokenData, err
+	return s.RefreshTokenData, nil
 }
 
 func (s *persistentStore) ValidFor() time.Duration {
-	err := s.getPersistentTokenData()
-	if err != nil {
+	if err := s.refresh(); err != nil {
 		return 0
 	}
 	s.mu.RLock()
@@ -246,58 +356,64 @@ func (s *persistentStore) ValidFor() time.Duration {
 }
 
 func (s *persistentStore) Update(r *TokenRefreshResponse) error {
-	f, err := os.Create("/tmp/tokenStore")
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Update in-memory data
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	old := s.toTokenRefreshResponse()
+	wasLoaded := s.loaded
 
 	s.AccessTokenData = r.AccessToken
 	s.RefreshTokenData = r.RefreshToken
 	s.ValidUntilData = generateValidUntil(r)
+	s.loaded = true
 
-	// Write token data to file to be accessed later
 	jsonData, err := json.Marshal(s.persistentStoreData)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	err = s.backend.Save(jsonData)
+	s.mu.Unlock()
 	if err != nil {
 		return err
 	}
-	f.Write(jsonData)
 
-	return err
+	if wasLoaded {
+		notifyRefresh(s.observers, old, r)
+	} else {
+		notifyLoad(s.observers, r)
+	}
+	return nil
+}
+
+func (s *persistentStore) NotifyRefreshError(err error) {
+	notifyRefreshError(s.observers, err)
 }
 
-// NewPersistentTokenStore is a ToeknStorer with persistence to disk
-func NewPersistentTokenStore(r *TokenRefreshResponse) (TokenStorer, error) {
-	s := &persistentStore{}
-	// update persistent storage
+// NewPersistentTokenStore is a TokenStorer which persists to backend. If
+// backend already holds token data, e.g. from a prior process sharing the
+// same file/keyring entry, it resumes from that instead of overwriting it
+// with r; r is only written if backend is empty or unreadable. Observers
+// are notified of the initial token via OnLoad, and of subsequent
+// refreshes or backend reloads via OnRefresh/OnLoad.
+func NewPersistentTokenStore(backend Backend, r *TokenRefreshResponse, observers ...TokenObserver) (TokenStorer, error) {
+	s := &persistentStore{backend: backend, observers: observers}
+	if err := s.refresh(); err == nil {
+		return s, nil
+	}
 	if err := s.Update(r); err != nil {
 		return nil, err
 	}
-
 	return s, nil
 }
 
-// getPersistentTokenData returns the token data stored in a local file
-func (s *persistentStore) getPersistentTokenData() error {
-	// TODO(sfunkhouser): make this file configurable
-	f, err := os.Open("/tmp/tokenStore")
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Update in-memory data
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	jsonData, err := ioutil.ReadAll(f)
-	json.Unmarshal(jsonData, &s.persistentStoreData)
-
-	return err
+// NewDefaultPersistentTokenStore is a TokenStorer persisted to
+// /tmp/tokenStore with mode 0600, the fixed path the original
+// NewPersistentTokenStore used. Like NewPersistentTokenStore, it resumes
+// from token data already in that file rather than overwriting it with r.
+//
+// Deprecated: call NewPersistentTokenStore with a FileBackend so the
+// storage location and permissions can be configured.
+func NewDefaultPersistentTokenStore(r *TokenRefreshResponse, observers ...TokenObserver) (TokenStorer, error) {
+	return NewPersistentTokenStore(NewFileBackend("/tmp/tokenStore", 0600), r, observers...)
 }
 
 // generateValidUntil returns the time the token expires with an added buffer
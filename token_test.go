@@ -0,0 +1,59 @@
+package egobee
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestNewPersistentTokenStore_ResumesFromExistingBackend guards against a
+// second construction against a backend that already has data (e.g. a
+// second process, or a restart) clobbering whatever was persisted there
+// with the seed TokenRefreshResponse it happens to be passed.
+func TestNewPersistentTokenStore_ResumesFromExistingBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	backend := NewFileBackend(path, 0600)
+
+	first, err := NewPersistentTokenStore(backend, &TokenRefreshResponse{
+		AccessToken:  "acc1",
+		RefreshToken: "ref1",
+	})
+	if err != nil {
+		t.Fatalf("first NewPersistentTokenStore: %v", err)
+	}
+	if got := first.AccessToken(); got != "acc1" {
+		t.Fatalf("first store AccessToken: got %q, want %q", got, "acc1")
+	}
+
+	second, err := NewPersistentTokenStore(NewFileBackend(path, 0600), &TokenRefreshResponse{
+		AccessToken:  "placeholder",
+		RefreshToken: "placeholder",
+	})
+	if err != nil {
+		t.Fatalf("second NewPersistentTokenStore: %v", err)
+	}
+	if got := second.AccessToken(); got != "acc1" {
+		t.Errorf("second store AccessToken: got %q, want %q (should resume from backend, not overwrite it)", got, "acc1")
+	}
+	if got, err := second.RefreshToken(); err != nil || got != "ref1" {
+		t.Errorf("second store RefreshToken: got (%q, %v), want (%q, nil)", got, err, "ref1")
+	}
+}
+
+// TestNewPersistentTokenStore_SeedsEmptyBackend covers the complementary
+// case: a backend with nothing persisted yet should be seeded with r, not
+// left empty.
+func TestNewPersistentTokenStore_SeedsEmptyBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	backend := NewFileBackend(path, 0600)
+
+	s, err := NewPersistentTokenStore(backend, &TokenRefreshResponse{
+		AccessToken:  "acc1",
+		RefreshToken: "ref1",
+	})
+	if err != nil {
+		t.Fatalf("NewPersistentTokenStore: %v", err)
+	}
+	if got := s.AccessToken(); got != "acc1" {
+		t.Errorf("AccessToken: got %q, want %q", got, "acc1")
+	}
+}